@@ -26,8 +26,10 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 type BlockValidator struct {
@@ -49,6 +51,17 @@ type BlockValidator struct {
 	sendValidationsChan chan interface{}
 	checkProgressChan   chan interface{}
 	progressChan        chan uint64
+
+	spawner validationSpawner
+	db      ethdb.Database
+
+	metrics             *validatorMetrics
+	events              chan ValidationEvent
+	sequencerBatchCount int32
+	latestHeadBlock     uint64
+
+	divergenceHandler DivergenceHandler
+	halted            int32
 }
 
 type BlockValidatorConfig struct {
@@ -59,6 +72,58 @@ type BlockValidatorConfig struct {
 	InitialMachineCachePath string
 	ConcurrentRunsLimit     int // 0 - default (CPU#)
 	BlocksToRecord          []uint64
+
+	// WorkerEndpoints, if non-empty, are gRPC addresses of ValidationServer
+	// processes to pool validation jobs across instead of running them
+	// in-process. This lets ConcurrentRunsLimit scale across machines
+	// rather than being bound to runtime.NumCPU() on a single host.
+	WorkerEndpoints []string
+
+	// ValidatorDbPath, if non-empty, persists validation progress so a
+	// restart resumes at posNext instead of re-validating from block 0.
+	// Relative paths are taken under RootPath.
+	ValidatorDbPath string
+
+	// MetricsRegisterer, if non-nil, is where BlockValidator registers its
+	// Prometheus metrics. Embedders that already run their own registry can
+	// plug it in here. If nil, the metrics are kept but registered nowhere,
+	// not even in the default registry.
+	MetricsRegisterer prometheus.Registerer
+
+	// WebhookURL, if non-empty, receives a JSON POST of every ValidationEvent
+	// (validation succeeded/failed, replay artifact written), in addition to
+	// the events being available on BlockValidator.Events().
+	WebhookURL string
+
+	// DivergencePolicy decides what happens to validation progress after a
+	// divergence is reported to DivergenceHandler. Defaults to
+	// PanicOnDivergence.
+	DivergencePolicy DivergencePolicy
+	// DivergenceHandler is notified of every validation divergence; if nil,
+	// a handler that only logs is used. It runs regardless of
+	// DivergencePolicy.
+	DivergenceHandler DivergenceHandler
+
+	// SnapshotStepInterval, if non-zero and SnapshotCacheDir is set,
+	// opportunistically snapshots the
+	// machine every that-many steps during validation (on top of the single
+	// pre-host-IO snapshot InitialMachineCachePath already keeps), so a
+	// later validation with the same start state can resume from the
+	// nearest snapshot instead of replaying from the beginning.
+	SnapshotStepInterval uint64
+	// SnapshotCacheDir holds those snapshots; relative paths are taken
+	// under RootPath. If empty, no snapshots are taken.
+	SnapshotCacheDir string
+	// SnapshotCacheMaxEntries bounds how many snapshots are kept on disk;
+	// 0 means unbounded.
+	SnapshotCacheMaxEntries int
+	// SnapshotCacheMaxAge prunes snapshots older than this on load; 0 means
+	// never prune by age.
+	SnapshotCacheMaxAge time.Duration
+	// VerifySnapshotCache re-derives a loaded snapshot's machine hash and
+	// compares it against the hash recorded when it was saved, to detect
+	// on-disk corruption before trusting the snapshot.
+	VerifySnapshotCache bool
 }
 
 var DefaultBlockValidatorConfig = BlockValidatorConfig{
@@ -69,6 +134,7 @@ var DefaultBlockValidatorConfig = BlockValidatorConfig{
 	InitialMachineCachePath: "initial-machine-cache",
 	ConcurrentRunsLimit:     0,
 	BlocksToRecord:          []uint64{},
+	ValidatorDbPath:         "validator_db",
 }
 
 func init() {
@@ -99,7 +165,11 @@ type blockValidatorGlobals struct {
 	initialized       bool
 	validationEntries sync.Map
 	sequencerBatches  sync.Map
-	inboxTracker      DelayedMessageReader
+	// sequencerBatchesRaw mirrors sequencerBatches but keeps the plain
+	// []byte a batch was built from, so validate() can hand it to a remote
+	// validationSpawner without having to reverse a cgo CByteArray.
+	sequencerBatchesRaw sync.Map
+	inboxTracker        DelayedMessageReader
 }
 
 var validatorStatic blockValidatorGlobals
@@ -160,19 +230,27 @@ func (l posToValidateList) StupidSearchPos(pos uint64) int {
 	return idx
 }
 
-func NewBlockValidator(inbox DelayedMessageReader, streamer BlockValidatorRegistrer, config *BlockValidatorConfig) *BlockValidator {
+func loadBaseMachine(config *BlockValidatorConfig) (*ArbitratorMachine, error) {
 	moduleList := []string{}
 	for _, module := range config.ModulePaths {
 		moduleList = append(moduleList, filepath.Join(config.RootPath, module))
 	}
 	cModuleList := CreateCStringList(moduleList)
 	cBinPath := C.CString(filepath.Join(config.RootPath, config.ProverBinPath))
+	defer C.free(unsafe.Pointer(cBinPath))
+	defer FreeCStringList(cModuleList, len(moduleList))
 
 	cZeroPreimages := C.CMultipleByteArrays{}
 	cZeroPreimages.len = 0
 	baseMachine := C.arbitrator_load_machine(cBinPath, cModuleList, C.intptr_t(len(moduleList)), C.GlobalState{}, cZeroPreimages)
-	FreeCStringList(cModuleList, len(moduleList))
-	C.free(unsafe.Pointer(cBinPath))
+	return machineFromPointer(baseMachine), nil
+}
+
+func NewBlockValidator(inbox DelayedMessageReader, streamer BlockValidatorRegistrer, config *BlockValidatorConfig) *BlockValidator {
+	baseMachine, err := loadBaseMachine(config)
+	if err != nil {
+		panic(err)
+	}
 	if validatorStatic.initialized {
 		panic("creating block validator when one exists")
 	}
@@ -183,14 +261,43 @@ func NewBlockValidator(inbox DelayedMessageReader, streamer BlockValidatorRegist
 	if concurrent == 0 {
 		concurrent = runtime.NumCPU()
 	}
+	db, err := openValidatorDb(config)
+	if err != nil {
+		panic(err)
+	}
+
+	var spawner validationSpawner
+	if len(config.WorkerEndpoints) > 0 {
+		coordinator, err := newRemoteValidationCoordinator(config.WorkerEndpoints)
+		if err != nil {
+			panic(err)
+		}
+		spawner = coordinator
+		log.Info("block validator dispatching to remote workers", "endpoints", config.WorkerEndpoints)
+	} else {
+		snapshots, err := newSnapshotCache(config)
+		if err != nil {
+			panic(err)
+		}
+		spawner = &localSpawner{baseMachine: baseMachine, snapshots: snapshots}
+	}
+	divergenceHandler := config.DivergenceHandler
+	if divergenceHandler == nil {
+		divergenceHandler = defaultDivergenceHandler()
+	}
 	validator := &BlockValidator{
 		posNextSend:         0,
 		sendValidationsChan: make(chan interface{}),
 		checkProgressChan:   make(chan interface{}),
 		progressChan:        make(chan uint64),
-		baseMachine:         machineFromPointer(baseMachine),
+		baseMachine:         baseMachine,
 		concurrentRunsLimit: int32(concurrent),
 		config:              config,
+		spawner:             spawner,
+		db:                  db,
+		metrics:             newValidatorMetrics(config.MetricsRegisterer),
+		events:              make(chan ValidationEvent, eventsChanSize),
+		divergenceHandler:   divergenceHandler,
 	}
 	streamer.SetBlockValidator(validator)
 	inbox.SetBlockValidator(validator)
@@ -219,13 +326,21 @@ func (v *BlockValidator) NewBlock(block *types.Block, prevHeader *types.Header,
 
 var launchTime = time.Now().Format("2006_01_02__15_04")
 
-func (v *BlockValidator) writeToFile(validationEntry *validationEntry, start, end PosInSequencer, c_preimages C.CMultipleByteArrays, sequencerCByte C.CByteArray, delayedCByte C.CByteArray) error {
+func (v *BlockValidator) writeToFile(validationEntry *validationEntry, input *ValidationInput, result *ValidationResult, c_preimages C.CMultipleByteArrays, sequencerCByte C.CByteArray, delayedCByte C.CByteArray) error {
+	start, end := input.Start, input.End
 	outDirPath := filepath.Join(v.config.RootPath, v.config.OutputPath, launchTime, fmt.Sprintf("block_%d", validationEntry.BlockNumber))
 	err := os.MkdirAll(outDirPath, 0777)
 	if err != nil {
 		return err
 	}
 
+	artifact := v.newReplayArtifact(validationEntry, input, result)
+	if err := WriteReplayArtifact(filepath.Join(outDirPath, "replay.json"), artifact); err != nil {
+		return err
+	}
+
+	// run-prover.sh is kept purely as a convenience wrapper, derived from
+	// the fields above; replay.json is the canonical record of this run.
 	cmdFile, err := os.Create(filepath.Join(outDirPath, "run-prover.sh"))
 	if err != nil {
 		return err
@@ -305,6 +420,7 @@ func (v *BlockValidator) writeToFile(validationEntry *validationEntry, start, en
 
 func (v *BlockValidator) validate(ctx context.Context, validationEntry *validationEntry, start, end PosInSequencer) {
 	log.Info("starting validation for block", "blockNr", validationEntry.BlockNumber, "start", start, "end", end)
+	validationStart := time.Now()
 	if !validatorStatic.initialized {
 		log.Error("validator: validatorStatic not initialized")
 		return
@@ -313,15 +429,8 @@ func (v *BlockValidator) validate(ctx context.Context, validationEntry *validati
 		log.Error("validator: validate got bad args", "block.end", validationEntry.Pos, "end", end.Pos)
 		return
 	}
-	c_preimages, err := v.preimageCache.PrepareMultByteArrays(validationEntry.Preimages)
-	defer C.free(unsafe.Pointer(c_preimages.ptr))
-	if err != nil {
-		log.Error("validator: failed prepare arrays", "err", err)
-		return
-	}
 	validationEntry.SeqMsgNr = start.BatchNum
 	validationEntry.Running = true
-	gsStart := CreateGlobalState(start.BatchNum, start.PosInBatch, validationEntry.PrevBlockHash)
 
 	seqEntry, found := validatorStatic.sequencerBatches.Load(start.BatchNum)
 	if !found {
@@ -333,39 +442,49 @@ func (v *BlockValidator) validate(ctx context.Context, validationEntry *validati
 		log.Error("sequencer message bad format", "pos", start.Pos, "msgNum", validationEntry.SeqMsgNr)
 		runtime.Goexit()
 	}
+	seqMsgRaw, found := validatorStatic.sequencerBatchesRaw.Load(start.BatchNum)
+	if !found {
+		log.Error("didn't find raw sequencer message", "pos", start.Pos, "msgNum", validationEntry.SeqMsgNr)
+		runtime.Goexit()
+	}
 
-	mach := v.baseMachine.Clone()
-	C.arbitrator_add_preimages(mach.ptr, c_preimages)
-	mach.SetGlobalState(gsStart)
-	mach.AddSequencerInboxMessage(start.BatchNum, seqCByte)
-	var delayedByte C.CByteArray
+	var delayedMsgRaw []byte
 	if validationEntry.HasDelayedMsg {
-		msg, err := validatorStatic.inboxTracker.GetDelayedMessageBytes(validationEntry.DelayedMsgNr)
+		var err error
+		delayedMsgRaw, err = validatorStatic.inboxTracker.GetDelayedMessageBytes(validationEntry.DelayedMsgNr)
 		if err != nil {
 			log.Error("error while trying to read delayed msg for proving", "err", err, "seq", validationEntry.DelayedMsgNr, "pos", start.Pos)
 			runtime.Goexit()
 		}
-		delayedByte = CreateCByteArray(msg)
-		mach.AddDelayedInboxMessage(validationEntry.DelayedMsgNr, delayedByte)
 	}
 
-	var steps uint64
-	for mach.IsRunning() {
-		var count uint64 = 100000000
-		err = mach.Step(ctx, count)
-		if err != nil {
-			if !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) {
-				log.Error("running machine failed", "err", err)
-				panic("Failed to run machine: " + err.Error())
-			}
+	input, err := v.newValidationInput(validationEntry, start, end, seqMsgRaw.([]byte), delayedMsgRaw)
+	if err != nil {
+		log.Error("validator: failed to prepare validation input", "err", err)
+		return
+	}
+
+	result, err := v.spawner.Launch(ctx, input)
+	if err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
 			return
 		}
-		steps += count
-		log.Info("validation", "block", validationEntry.BlockNumber, "steps", steps)
+		log.Error("running machine failed", "err", err)
+		panic("Failed to run machine: " + err.Error())
+	}
+
+	c_preimages, err := v.preimageCache.PrepareMultByteArrays(validationEntry.Preimages)
+	defer C.free(unsafe.Pointer(c_preimages.ptr))
+	if err != nil {
+		log.Error("validator: failed prepare arrays", "err", err)
+		return
+	}
+	var delayedByte C.CByteArray
+	if validationEntry.HasDelayedMsg {
+		delayedByte = CreateCByteArray(delayedMsgRaw)
 	}
-	gsEnd := mach.GetGlobalState()
 
-	resBatch, resPosInBatch, resHash := ParseGlobalState(gsEnd)
+	resBatch, resPosInBatch, resHash := result.Batch, result.PosInBatch, result.BlockHash
 
 	writeThisBlock := false
 
@@ -386,16 +505,55 @@ func (v *BlockValidator) validate(ctx context.Context, validationEntry *validati
 	}
 
 	if writeThisBlock {
-		err = v.writeToFile(validationEntry, start, end, c_preimages, seqCByte, delayedByte)
+		artifactPath := filepath.Join(v.config.RootPath, v.config.OutputPath, launchTime, fmt.Sprintf("block_%d", validationEntry.BlockNumber), "replay.json")
+		err = v.writeToFile(validationEntry, input, result, c_preimages, seqCByte, delayedByte)
 		if err != nil {
 			log.Error("failed to write file", "err", err)
+		} else {
+			v.emitEvent(ValidationEvent{Type: ValidationEventArtifactWritten, BlockNumber: validationEntry.BlockNumber, ArtifactPath: artifactPath})
 		}
 	}
 
+	v.metrics.observeValidation(result.Steps, time.Since(validationStart), resultValid)
+
+	// haltQuarantined keeps validationEntry invalid forever so
+	// ProgressValidated never advances past the divergent position,
+	// matching HaltValidationOnly's contract of quarantining the position
+	// instead of reporting past it like ContinueAndReport.
+	haltQuarantined := false
+
 	if !resultValid {
+		v.emitEvent(ValidationEvent{Type: ValidationEventFailed, BlockNumber: validationEntry.BlockNumber, Batch: resBatch, PosInBatch: resPosInBatch, BlockHash: resHash})
 		log.Error("validation failed", "startPos", start.Pos, "batch_exp", end.BatchAfter, "batch_actual", resBatch, "pos_exp", end.PosAfter, "pos_actual", resPosInBatch, "hash_exp", validationEntry.BlockHash, "hash_actual", resHash)
 		log.Error("validation failed", "expHeader", validationEntry.BlockHeader)
-		panic("validation failed. quitting..")
+
+		divInfo := &DivergenceInfo{
+			Entry:        validationEntry,
+			Start:        start,
+			End:          end,
+			ArtifactDir:  filepath.Join(v.config.RootPath, v.config.OutputPath, launchTime, fmt.Sprintf("block_%d", validationEntry.BlockNumber)),
+			Expected:     ValidationResult{Batch: end.BatchAfter, PosInBatch: end.PosAfter, BlockHash: validationEntry.BlockHash},
+			Actual:       *result,
+			Preimages:    input.Preimages,
+			SequencerMsg: input.SequencerMsg,
+			DelayedMsg:   input.DelayedMsg,
+		}
+		if handlerErr := v.divergenceHandler.OnDivergence(divInfo); handlerErr != nil {
+			log.Error("divergence handler failed", "err", handlerErr)
+		}
+
+		switch v.config.DivergencePolicy {
+		case HaltValidationOnly:
+			atomic.StoreInt32(&v.halted, 1)
+			log.Error("halting further validation after divergence", "pos", validationEntry.Pos)
+			haltQuarantined = true
+		case ContinueAndReport:
+			validationEntry.Valid = true
+		default:
+			panic("validation failed. quitting..")
+		}
+	} else {
+		v.emitEvent(ValidationEvent{Type: ValidationEventSucceeded, BlockNumber: validationEntry.BlockNumber, Batch: resBatch, PosInBatch: resPosInBatch, BlockHash: resHash})
 	}
 
 	err = v.preimageCache.RemoveFromCache(validationEntry.Preimages)
@@ -405,16 +563,29 @@ func (v *BlockValidator) validate(ctx context.Context, validationEntry *validati
 	for _, cbyte := range validationEntry.MsgsAllocated {
 		DestroyCByteArray(cbyte.data)
 	}
-	atomic.AddInt32(&v.atomicValidationsRunning, -1)
+	v.metrics.setRunning(atomic.AddInt32(&v.atomicValidationsRunning, -1))
 	validationEntry.MsgsAllocated = nil
 	validationEntry.Preimages = nil
+
+	if haltQuarantined {
+		log.Warn("validation halted at quarantined divergence", "blockNr", validationEntry.BlockNumber)
+		return
+	}
+
 	validationEntry.Valid = true // after that - validation entry could be deleted from map
-	log.Info("validation succeeded", "blockNr", validationEntry.BlockNumber)
+	if resultValid {
+		log.Info("validation succeeded", "blockNr", validationEntry.BlockNumber)
+	} else {
+		log.Warn("continuing past reported validation divergence", "blockNr", validationEntry.BlockNumber)
+	}
 	v.checkProgressChan <- struct{}{}
 	v.sendValidationsChan <- struct{}{}
 }
 
 func (v *BlockValidator) sendValidations(ctx context.Context) {
+	if atomic.LoadInt32(&v.halted) != 0 {
+		return
+	}
 	v.posToValidateMutex.Lock()
 	defer v.posToValidateMutex.Unlock()
 	sort.Sort(v.posToValidate)
@@ -442,7 +613,7 @@ func (v *BlockValidator) sendValidations(ctx context.Context) {
 		if len(v.posToValidate) <= idx || v.posToValidate[idx].Pos != validationEntry.Pos {
 			return
 		}
-		atomic.AddInt32(&v.atomicValidationsRunning, 1)
+		v.metrics.setRunning(atomic.AddInt32(&v.atomicValidationsRunning, 1))
 		go v.validate(ctx, validationEntry, v.posToValidate[0], v.posToValidate[idx])
 		v.posNextSend = validationEntry.Pos + 1
 		v.posToValidate = v.posToValidate[idx+1:]
@@ -498,9 +669,19 @@ func (v *BlockValidator) ProgressValidated() {
 				continue
 			}
 			DestroyCByteArray(cbyte)
+			validatorStatic.sequencerBatchesRaw.Delete(batch)
+			v.metrics.setSequencerBatchCacheSize(int(atomic.AddInt32(&v.sequencerBatchCount, -1)))
 		}
+		v.batchNrValidated = validationEntry.SeqMsgNr
 		v.posNext = validationEntry.Pos + 1
 		v.blocksValidated = validationEntry.BlockNumber
+		v.metrics.setLag(v.blocksValidated, atomic.LoadUint64(&v.latestHeadBlock))
+		if err := v.persistCheckpoint(); err != nil {
+			log.Error("validator: failed to persist checkpoint", "err", err)
+		}
+		if err := v.compactValidated(validationEntry.Pos, v.batchNrValidated); err != nil {
+			log.Error("validator: failed to compact validated db entries", "err", err)
+		}
 		select {
 		case v.progressChan <- v.blocksValidated:
 		default:
@@ -528,9 +709,27 @@ func (v *BlockValidator) BlocksValidated() uint64 {
 	return v.blocksValidated
 }
 
+// SetLatestHeadBlock lets the embedder report the chain's current head, so
+// the validation_lag_blocks metric reflects how far behind catch-up
+// validation is running.
+func (v *BlockValidator) SetLatestHeadBlock(blockNumber uint64) {
+	atomic.StoreUint64(&v.latestHeadBlock, blockNumber)
+	v.metrics.setLag(v.blocksValidated, blockNumber)
+}
+
 func (v *BlockValidator) ProcessBatches(batches map[uint64][]byte, posData []PosInSequencer) {
 	for batchNr, msg := range batches {
 		validatorStatic.sequencerBatches.Store(batchNr, CreateCByteArray(msg))
+		validatorStatic.sequencerBatchesRaw.Store(batchNr, msg)
+		if err := v.persistBatch(batchNr, msg); err != nil {
+			log.Error("validator: failed to persist sequencer batch", "batch", batchNr, "err", err)
+		}
+		v.metrics.setSequencerBatchCacheSize(int(atomic.AddInt32(&v.sequencerBatchCount, 1)))
+	}
+	for _, pos := range posData {
+		if err := v.persistPending(pos); err != nil {
+			log.Error("validator: failed to persist pending position", "pos", pos.Pos, "err", err)
+		}
 	}
 	v.posToValidateMutex.Lock()
 	v.posToValidate = append(v.posToValidate, posData...)
@@ -617,13 +816,60 @@ func (v *BlockValidator) cacheBaseMachineUntilHostIo(ctx context.Context) error
 	return nil
 }
 
+// resumeFromDb reloads the last persisted checkpoint and re-queues whatever
+// positions were still pending, so validation resumes at posNext instead of
+// starting over from block 0 after a crash or an orderly shutdown.
+func (v *BlockValidator) resumeFromDb() error {
+	checkpoint, err := v.loadCheckpoint()
+	if err != nil {
+		return err
+	}
+	if checkpoint == nil {
+		return nil
+	}
+	v.posNext = checkpoint.PosNext
+	v.batchNrValidated = checkpoint.BatchNrValidated
+	v.blocksValidated = checkpoint.BlocksValidated
+	v.posNextSend = checkpoint.PosNext
+
+	pending, err := v.loadPendingPositions()
+	if err != nil {
+		return err
+	}
+	for _, pos := range pending {
+		if msg, found, err := v.loadBatchBytes(pos.BatchNum); err != nil {
+			return err
+		} else if found {
+			if _, alreadyLoaded := validatorStatic.sequencerBatches.Load(pos.BatchNum); !alreadyLoaded {
+				validatorStatic.sequencerBatches.Store(pos.BatchNum, CreateCByteArray(msg))
+				validatorStatic.sequencerBatchesRaw.Store(pos.BatchNum, msg)
+			}
+		}
+	}
+	v.posToValidateMutex.Lock()
+	v.posToValidate = append(v.posToValidate, pending...)
+	v.posToValidateMutex.Unlock()
+
+	log.Info("block validator resuming from persisted checkpoint", "posNext", v.posNext, "blocksValidated", v.blocksValidated, "pending", len(pending))
+	return nil
+}
+
 func (v *BlockValidator) Start(ctx context.Context) error {
+	if err := v.resumeFromDb(); err != nil {
+		return err
+	}
 	err := v.cacheBaseMachineUntilHostIo(ctx)
 	if err != nil {
 		return err
 	}
 	v.startProgressLoop(ctx)
 	v.startValidationLoop(ctx)
+	if closer, ok := v.spawner.(interface{ Close() }); ok {
+		go func() {
+			<-ctx.Done()
+			closer.Close()
+		}()
+	}
 	return nil
 }
 
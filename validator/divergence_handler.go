@@ -0,0 +1,79 @@
+//
+// Copyright 2021, Offchain Labs, Inc. All rights reserved.
+//
+
+package validator
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// DivergencePolicy controls what BlockValidator does with its own progress
+// once a DivergenceHandler has been notified of a validation failure. The
+// handler's side effects (logging, posting an artifact, opening a
+// challenge) always run; the policy only decides whether validation itself
+// stops the node, stalls at the divergent position, or keeps going.
+type DivergencePolicy int
+
+const (
+	// PanicOnDivergence kills the node, as validate() has always done.
+	// This remains the default: a divergence is a serious bug somewhere
+	// (bad state, bad proof, bad prover) and the safest reaction is to
+	// stop before anything downstream trusts the result.
+	PanicOnDivergence DivergencePolicy = iota
+	// HaltValidationOnly stops dispatching new validations and leaves the
+	// divergent position pending forever, but otherwise keeps the node
+	// running so the rest of the system (e.g. the sequencer feed) is
+	// unaffected.
+	HaltValidationOnly
+	// ContinueAndReport marks the divergent position as handled and lets
+	// validation keep advancing past it. Only safe when the configured
+	// DivergenceHandler takes real remedial action (e.g. opening an L1
+	// challenge) instead of only logging.
+	ContinueAndReport
+)
+
+// DivergenceInfo is everything a DivergenceHandler needs to act on a
+// validation failure without re-running validation: the expected and actual
+// end state, and the exact inputs (preimages, sequencer/delayed message
+// bytes) that would let it reconstruct an on-chain proof.
+type DivergenceInfo struct {
+	Entry        *validationEntry
+	Start        PosInSequencer
+	End          PosInSequencer
+	ArtifactDir  string
+	Expected     ValidationResult
+	Actual       ValidationResult
+	Preimages    map[common.Hash][]byte
+	SequencerMsg []byte
+	DelayedMsg   []byte
+}
+
+// DivergenceHandler is notified whenever a block's replayed end state
+// doesn't match what the chain claims it should be. Implementations can
+// keep the node syncing while quarantining the divergent position, post the
+// replay artifact to a dispute-submission endpoint, or open a challenge on
+// L1 via an injected client.
+type DivergenceHandler interface {
+	OnDivergence(info *DivergenceInfo) error
+}
+
+// logDivergenceHandler only logs; BlockValidator's DivergencePolicy decides
+// what, if anything, happens to validation progress afterward.
+type logDivergenceHandler struct{}
+
+func (logDivergenceHandler) OnDivergence(info *DivergenceInfo) error {
+	log.Error("validation divergence",
+		"block", info.Entry.BlockNumber,
+		"batch_exp", info.Expected.Batch, "batch_actual", info.Actual.Batch,
+		"pos_exp", info.Expected.PosInBatch, "pos_actual", info.Actual.PosInBatch,
+		"hash_exp", info.Expected.BlockHash, "hash_actual", info.Actual.BlockHash,
+		"artifactDir", info.ArtifactDir,
+	)
+	return nil
+}
+
+func defaultDivergenceHandler() DivergenceHandler {
+	return logDivergenceHandler{}
+}
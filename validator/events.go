@@ -0,0 +1,84 @@
+//
+// Copyright 2021, Offchain Labs, Inc. All rights reserved.
+//
+
+package validator
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// ValidationEventType distinguishes the kinds of events a BlockValidator
+// emits, so subscribers can page on divergences instead of discovering them
+// by watching for the node to panic.
+type ValidationEventType string
+
+const (
+	ValidationEventSucceeded       ValidationEventType = "validation_succeeded"
+	ValidationEventFailed          ValidationEventType = "validation_failed"
+	ValidationEventArtifactWritten ValidationEventType = "replay_artifact_written"
+)
+
+// ValidationEvent is a single point-in-time fact about validation progress,
+// delivered over BlockValidator.Events() and optionally POSTed as JSON to a
+// configured webhook.
+type ValidationEvent struct {
+	Type         ValidationEventType `json:"type"`
+	Time         time.Time           `json:"time"`
+	BlockNumber  uint64              `json:"blockNumber"`
+	Batch        uint64              `json:"batch,omitempty"`
+	PosInBatch   uint64              `json:"posInBatch,omitempty"`
+	BlockHash    common.Hash         `json:"blockHash,omitempty"`
+	ArtifactPath string              `json:"artifactPath,omitempty"`
+}
+
+// eventsChanSize bounds how many undelivered events BlockValidator will
+// hold before it starts dropping them; a slow or absent subscriber must
+// never be able to stall validation.
+const eventsChanSize = 64
+
+// webhookTimeout bounds how long postWebhook will wait on a slow or hanging
+// WebhookURL. Without it, a single bad endpoint leaks one goroutine and one
+// open connection per validation event during a long catch-up run.
+const webhookTimeout = 10 * time.Second
+
+var webhookClient = &http.Client{Timeout: webhookTimeout}
+
+// Events returns a channel of validation events. Validation itself never
+// blocks on this channel: once eventsChanSize events are waiting, each new
+// event is dropped rather than stalling the validator.
+func (v *BlockValidator) Events() <-chan ValidationEvent {
+	return v.events
+}
+
+func (v *BlockValidator) emitEvent(ev ValidationEvent) {
+	ev.Time = time.Now()
+	select {
+	case v.events <- ev:
+	default:
+		log.Warn("validator: dropping validation event, no receiver keeping up", "type", ev.Type, "block", ev.BlockNumber)
+	}
+	if v.config.WebhookURL != "" {
+		go v.postWebhook(ev)
+	}
+}
+
+func (v *BlockValidator) postWebhook(ev ValidationEvent) {
+	encoded, err := json.Marshal(ev)
+	if err != nil {
+		log.Error("validator: failed to encode webhook event", "err", err)
+		return
+	}
+	resp, err := webhookClient.Post(v.config.WebhookURL, "application/json", bytes.NewReader(encoded))
+	if err != nil {
+		log.Error("validator: failed to post webhook event", "err", err)
+		return
+	}
+	resp.Body.Close()
+}
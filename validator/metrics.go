@@ -0,0 +1,130 @@
+//
+// Copyright 2021, Offchain Labs, Inc. All rights reserved.
+//
+
+package validator
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// validatorMetrics exposes BlockValidator's internal state to Prometheus:
+// how much validation work has happened, whether it's succeeding, and how
+// far behind the chain head it's running. It's built from a
+// prometheus.Registerer instead of the global default registry so embedders
+// can fold it into their own metrics namespace.
+type validatorMetrics struct {
+	blocksValidated     prometheus.Counter
+	validationFailures  prometheus.Counter
+	validationsRunning  prometheus.Gauge
+	validationSteps     prometheus.Histogram
+	validationDuration  prometheus.Histogram
+	validationLag       prometheus.Gauge
+	preimageCacheSize   prometheus.Gauge
+	preimageCacheHits   prometheus.Counter
+	preimageCacheMisses prometheus.Counter
+	sequencerBatchCache prometheus.Gauge
+}
+
+func newValidatorMetrics(reg prometheus.Registerer) *validatorMetrics {
+	m := &validatorMetrics{
+		blocksValidated: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "arb", Subsystem: "validator", Name: "blocks_validated_total",
+			Help: "Number of blocks that have completed validation successfully.",
+		}),
+		validationFailures: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "arb", Subsystem: "validator", Name: "validation_failures_total",
+			Help: "Number of blocks whose validation result diverged from the expected global state.",
+		}),
+		validationsRunning: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "arb", Subsystem: "validator", Name: "validations_running",
+			Help: "Number of validations currently in flight.",
+		}),
+		validationSteps: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: "arb", Subsystem: "validator", Name: "validation_steps",
+			Help:    "Number of prover steps taken to validate a single block.",
+			Buckets: prometheus.ExponentialBuckets(1000, 10, 8),
+		}),
+		validationDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: "arb", Subsystem: "validator", Name: "validation_duration_seconds",
+			Help:    "Wall-clock time spent validating a single block.",
+			Buckets: prometheus.DefBuckets,
+		}),
+		validationLag: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "arb", Subsystem: "validator", Name: "validation_lag_blocks",
+			Help: "Difference between the chain head and the last validated block.",
+		}),
+		preimageCacheSize: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "arb", Subsystem: "validator", Name: "preimage_cache_size",
+			Help: "Number of preimages currently held in the validator's preimage cache.",
+		}),
+		preimageCacheHits: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "arb", Subsystem: "validator", Name: "preimage_cache_hits_total",
+			Help: "Preimage lookups served from the cache.",
+		}),
+		preimageCacheMisses: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "arb", Subsystem: "validator", Name: "preimage_cache_misses_total",
+			Help: "Preimage lookups that missed the cache.",
+		}),
+		sequencerBatchCache: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "arb", Subsystem: "validator", Name: "sequencer_batch_cache_size",
+			Help: "Number of sequencer batches currently cached awaiting validation.",
+		}),
+	}
+	if reg != nil {
+		reg.MustRegister(
+			m.blocksValidated,
+			m.validationFailures,
+			m.validationsRunning,
+			m.validationSteps,
+			m.validationDuration,
+			m.validationLag,
+			m.preimageCacheSize,
+			m.preimageCacheHits,
+			m.preimageCacheMisses,
+			m.sequencerBatchCache,
+		)
+	}
+	return m
+}
+
+// observeValidation records the outcome of a single validate() run.
+func (m *validatorMetrics) observeValidation(steps uint64, duration time.Duration, valid bool) {
+	if m == nil {
+		return
+	}
+	m.validationSteps.Observe(float64(steps))
+	m.validationDuration.Observe(duration.Seconds())
+	if valid {
+		m.blocksValidated.Inc()
+	} else {
+		m.validationFailures.Inc()
+	}
+}
+
+func (m *validatorMetrics) setRunning(n int32) {
+	if m == nil {
+		return
+	}
+	m.validationsRunning.Set(float64(n))
+}
+
+func (m *validatorMetrics) setLag(blocksValidated, latestHeadBlock uint64) {
+	if m == nil {
+		return
+	}
+	if latestHeadBlock < blocksValidated {
+		m.validationLag.Set(0)
+		return
+	}
+	m.validationLag.Set(float64(latestHeadBlock - blocksValidated))
+}
+
+func (m *validatorMetrics) setSequencerBatchCacheSize(n int) {
+	if m == nil {
+		return
+	}
+	m.sequencerBatchCache.Set(float64(n))
+}
@@ -0,0 +1,125 @@
+//
+// Copyright 2021, Offchain Labs, Inc. All rights reserved.
+//
+
+package validator
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// ReplayArtifactModule records a prover module and the hash it loaded with,
+// so a replay can be checked against the exact binaries that produced it.
+type ReplayArtifactModule struct {
+	Path string      `json:"path"`
+	Hash common.Hash `json:"hash"`
+}
+
+// ReplayArtifactPreimage is a single preimage keyed by its hash.
+type ReplayArtifactPreimage struct {
+	Hash common.Hash `json:"hash"`
+	Data []byte      `json:"data"`
+}
+
+// ReplayArtifact is a single machine-readable manifest capturing everything
+// needed to reproduce a block validation run, so downstream tooling (CI
+// bisect, fuzzers, dispute-game uploaders) can consume failures
+// programmatically instead of shelling out to run-prover.sh.
+type ReplayArtifact struct {
+	BlockNumber   uint64      `json:"blockNumber"`
+	PrevBlockHash common.Hash `json:"prevBlockHash"`
+
+	Start PosInSequencer `json:"start"`
+	End   PosInSequencer `json:"end"`
+
+	ExpectedBatch      uint64      `json:"expectedBatch"`
+	ExpectedPosInBatch uint64      `json:"expectedPosInBatch"`
+	ExpectedBlockHash  common.Hash `json:"expectedBlockHash"`
+
+	ActualBatch      uint64      `json:"actualBatch"`
+	ActualPosInBatch uint64      `json:"actualPosInBatch"`
+	ActualBlockHash  common.Hash `json:"actualBlockHash"`
+
+	Modules []ReplayArtifactModule `json:"modules"`
+
+	SequencerMsgNr uint64 `json:"sequencerMsgNr"`
+	SequencerMsg   []byte `json:"sequencerMsg"`
+
+	HasDelayedMsg bool   `json:"hasDelayedMsg"`
+	DelayedMsgNr  uint64 `json:"delayedMsgNr,omitempty"`
+	DelayedMsg    []byte `json:"delayedMsg,omitempty"`
+
+	Preimages []ReplayArtifactPreimage `json:"preimages"`
+}
+
+// moduleContentHash returns the sha256 content hash of the prover module at
+// path, so a manifest's module list can be checked against the exact binary
+// that produced it. A module that can't be read gets a zero hash; the
+// manifest is still written since the path alone is enough to investigate.
+func moduleContentHash(path string) common.Hash {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		log.Warn("replay artifact: failed to hash module, recording zero hash", "path", path, "err", err)
+		return common.Hash{}
+	}
+	return sha256.Sum256(content)
+}
+
+func (v *BlockValidator) newReplayArtifact(entry *validationEntry, input *ValidationInput, result *ValidationResult) *ReplayArtifact {
+	modules := make([]ReplayArtifactModule, 0, len(v.config.ModulePaths))
+	for _, module := range v.config.ModulePaths {
+		modules = append(modules, ReplayArtifactModule{Path: module, Hash: moduleContentHash(filepath.Join(v.config.RootPath, module))})
+	}
+	preimages := make([]ReplayArtifactPreimage, 0, len(input.Preimages))
+	for hash, data := range input.Preimages {
+		preimages = append(preimages, ReplayArtifactPreimage{Hash: hash, Data: data})
+	}
+	return &ReplayArtifact{
+		BlockNumber:        entry.BlockNumber,
+		PrevBlockHash:      input.PrevBlockHash,
+		Start:              input.Start,
+		End:                input.End,
+		ExpectedBatch:      input.End.BatchAfter,
+		ExpectedPosInBatch: input.End.PosAfter,
+		ExpectedBlockHash:  entry.BlockHash,
+		ActualBatch:        result.Batch,
+		ActualPosInBatch:   result.PosInBatch,
+		ActualBlockHash:    result.BlockHash,
+		Modules:            modules,
+		SequencerMsgNr:     entry.SeqMsgNr,
+		SequencerMsg:       input.SequencerMsg,
+		HasDelayedMsg:      input.HasDelayedMsg,
+		DelayedMsgNr:       input.DelayedMsgNr,
+		DelayedMsg:         input.DelayedMsg,
+		Preimages:          preimages,
+	}
+}
+
+// WriteReplayArtifact writes the manifest as indented JSON to path.
+func WriteReplayArtifact(path string, artifact *ReplayArtifact) error {
+	encoded, err := json.MarshalIndent(artifact, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, encoded, 0644)
+}
+
+// LoadReplayArtifact reads back a manifest written by WriteReplayArtifact,
+// for tooling that wants to reproduce a failing validation programmatically.
+func LoadReplayArtifact(path string) (*ReplayArtifact, error) {
+	encoded, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	artifact := &ReplayArtifact{}
+	if err := json.Unmarshal(encoded, artifact); err != nil {
+		return nil, err
+	}
+	return artifact, nil
+}
@@ -0,0 +1,100 @@
+//
+// Copyright 2021, Offchain Labs, Inc. All rights reserved.
+//
+
+package validator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestReplayArtifactRoundTrip(t *testing.T) {
+	artifact := &ReplayArtifact{
+		BlockNumber:        7,
+		Start:              PosInSequencer{Pos: 1, BatchNum: 2, PosInBatch: 3},
+		End:                PosInSequencer{Pos: 4, BatchNum: 5, PosInBatch: 6, BatchAfter: 5, PosAfter: 7},
+		ExpectedBatch:      5,
+		ExpectedPosInBatch: 7,
+		ExpectedBlockHash:  common.HexToHash("0xaa"),
+		ActualBatch:        5,
+		ActualPosInBatch:   7,
+		ActualBlockHash:    common.HexToHash("0xaa"),
+		Modules: []ReplayArtifactModule{
+			{Path: "replay.wasm", Hash: common.HexToHash("0xbb")},
+		},
+		SequencerMsgNr: 2,
+		SequencerMsg:   []byte("sequencer-msg"),
+		HasDelayedMsg:  true,
+		DelayedMsgNr:   9,
+		DelayedMsg:     []byte("delayed-msg"),
+		Preimages: []ReplayArtifactPreimage{
+			{Hash: common.HexToHash("0xcc"), Data: []byte("preimage-data")},
+		},
+	}
+
+	path := filepath.Join(t.TempDir(), "replay.json")
+	if err := WriteReplayArtifact(path, artifact); err != nil {
+		t.Fatalf("WriteReplayArtifact: %v", err)
+	}
+
+	loaded, err := LoadReplayArtifact(path)
+	if err != nil {
+		t.Fatalf("LoadReplayArtifact: %v", err)
+	}
+	if loaded.BlockNumber != artifact.BlockNumber {
+		t.Errorf("BlockNumber = %d, want %d", loaded.BlockNumber, artifact.BlockNumber)
+	}
+	if loaded.ExpectedBlockHash != artifact.ExpectedBlockHash {
+		t.Errorf("ExpectedBlockHash = %v, want %v", loaded.ExpectedBlockHash, artifact.ExpectedBlockHash)
+	}
+	if len(loaded.Modules) != 1 || loaded.Modules[0].Hash != artifact.Modules[0].Hash {
+		t.Errorf("Modules = %+v, want %+v", loaded.Modules, artifact.Modules)
+	}
+	if len(loaded.Preimages) != 1 || string(loaded.Preimages[0].Data) != "preimage-data" {
+		t.Errorf("Preimages = %+v, want a single preimage-data entry", loaded.Preimages)
+	}
+	if string(loaded.DelayedMsg) != "delayed-msg" || loaded.DelayedMsgNr != 9 {
+		t.Errorf("delayed msg fields = %q, %d; want delayed-msg, 9", loaded.DelayedMsg, loaded.DelayedMsgNr)
+	}
+}
+
+func TestModuleContentHash(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "module.wasm")
+	if err := os.WriteFile(path, []byte("module-bytes"), 0644); err != nil {
+		t.Fatalf("os.WriteFile: %v", err)
+	}
+
+	hash := moduleContentHash(path)
+	if hash == (common.Hash{}) {
+		t.Errorf("moduleContentHash(%q) = zero hash, want a content hash", path)
+	}
+	if again := moduleContentHash(path); again != hash {
+		t.Errorf("moduleContentHash is not deterministic: %v != %v", again, hash)
+	}
+
+	if missing := moduleContentHash(filepath.Join(dir, "missing.wasm")); missing != (common.Hash{}) {
+		t.Errorf("moduleContentHash(missing) = %v, want zero hash", missing)
+	}
+}
+
+func TestNewReplayArtifactResolvesModulePathsUnderRootPath(t *testing.T) {
+	rootPath := t.TempDir()
+	if err := os.WriteFile(filepath.Join(rootPath, "module.wasm"), []byte("module-bytes"), 0644); err != nil {
+		t.Fatalf("os.WriteFile: %v", err)
+	}
+
+	v := &BlockValidator{config: &BlockValidatorConfig{RootPath: rootPath, ModulePaths: []string{"module.wasm"}}}
+	artifact := v.newReplayArtifact(&validationEntry{}, &ValidationInput{}, &ValidationResult{})
+
+	if len(artifact.Modules) != 1 {
+		t.Fatalf("len(Modules) = %d, want 1", len(artifact.Modules))
+	}
+	if artifact.Modules[0].Hash == (common.Hash{}) {
+		t.Errorf("Modules[0].Hash = zero hash, want the content hash of %q resolved under RootPath", "module.wasm")
+	}
+}
@@ -0,0 +1,216 @@
+//
+// Copyright 2021, Offchain Labs, Inc. All rights reserved.
+//
+
+package validator
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// snapshotKey identifies the start state of a validation that validate()
+// opportunistically snapshots along the way. cacheBaseMachineUntilHostIo already caches the one
+// checkpoint right before host I/O; on long catch-up runs every block still
+// re-steps from there through potentially billions of instructions, so
+// snapshotCache adds further checkpoints along the way.
+type snapshotKey struct {
+	BaseMachineHash common.Hash
+	BatchNum        uint64
+	PosInBatch      uint64
+}
+
+func (k snapshotKey) dirName() string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s-%d-%d", k.BaseMachineHash, k.BatchNum, k.PosInBatch)))
+	return hex.EncodeToString(sum[:])
+}
+
+type snapshotEntry struct {
+	path      string
+	stepCount uint64
+	hash      common.Hash
+	touchedAt time.Time
+}
+
+// snapshotCache is an LRU of serialized machine states, keyed by the start
+// state of the validation that produced them plus how many steps into that
+// validation each snapshot was taken. It lets a later validation with the
+// same start state skip straight to the nearest snapshot before the point
+// it actually needs, instead of replaying from the very start.
+type snapshotCache struct {
+	dir              string
+	stepInterval     uint64
+	maxEntries       int
+	maxAge           time.Duration
+	verifyOnLoad     bool
+	mu               sync.Mutex
+	entriesByKeyPath map[string][]*snapshotEntry // keyDir -> snapshots, ascending stepCount
+	lru              []*snapshotEntry            // least-recently-touched first
+}
+
+func newSnapshotCache(config *BlockValidatorConfig) (*snapshotCache, error) {
+	if config.SnapshotStepInterval == 0 || config.SnapshotCacheDir == "" {
+		return nil, nil
+	}
+	dir := config.SnapshotCacheDir
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(config.RootPath, dir)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	c := &snapshotCache{
+		dir:              dir,
+		stepInterval:     config.SnapshotStepInterval,
+		maxEntries:       config.SnapshotCacheMaxEntries,
+		maxAge:           config.SnapshotCacheMaxAge,
+		verifyOnLoad:     config.VerifySnapshotCache,
+		entriesByKeyPath: make(map[string][]*snapshotEntry),
+	}
+	if err := c.scan(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *snapshotCache) scan() error {
+	keyDirs, err := ioutil.ReadDir(c.dir)
+	if err != nil {
+		return err
+	}
+	cutoff := time.Now().Add(-c.maxAge)
+	for _, keyDir := range keyDirs {
+		if !keyDir.IsDir() {
+			continue
+		}
+		keyPath := filepath.Join(c.dir, keyDir.Name())
+		files, err := ioutil.ReadDir(keyPath)
+		if err != nil {
+			log.Warn("snapshot cache: failed to read key dir", "dir", keyPath, "err", err)
+			continue
+		}
+		for _, file := range files {
+			if c.maxAge > 0 && file.ModTime().Before(cutoff) {
+				log.Info("snapshot cache: removing expired snapshot", "path", file.Name())
+				_ = os.Remove(filepath.Join(keyPath, file.Name()))
+				continue
+			}
+			stepCount, hash, ok := parseSnapshotFileName(file.Name())
+			if !ok {
+				continue
+			}
+			entry := &snapshotEntry{path: filepath.Join(keyPath, file.Name()), stepCount: stepCount, hash: hash, touchedAt: file.ModTime()}
+			c.entriesByKeyPath[keyPath] = append(c.entriesByKeyPath[keyPath], entry)
+			c.lru = append(c.lru, entry)
+		}
+	}
+	for _, entries := range c.entriesByKeyPath {
+		sort.Slice(entries, func(i, j int) bool { return entries[i].stepCount < entries[j].stepCount })
+	}
+	sort.Slice(c.lru, func(i, j int) bool { return c.lru[i].touchedAt.Before(c.lru[j].touchedAt) })
+	return nil
+}
+
+func parseSnapshotFileName(name string) (uint64, common.Hash, bool) {
+	name = strings.TrimSuffix(name, ".bin")
+	parts := strings.SplitN(name, "_", 2)
+	if len(parts) != 2 {
+		return 0, common.Hash{}, false
+	}
+	stepCount, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return 0, common.Hash{}, false
+	}
+	return stepCount, common.HexToHash(parts[1]), true
+}
+
+// nearestBefore returns the snapshot with the greatest step count that is
+// still <= targetSteps (0 meaning "any"), for the given key.
+func (c *snapshotCache) nearestBefore(key snapshotKey, targetSteps uint64) (path string, stepCount uint64, hash common.Hash, found bool) {
+	if c == nil {
+		return "", 0, common.Hash{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	keyPath := filepath.Join(c.dir, key.dirName())
+	entries := c.entriesByKeyPath[keyPath]
+	for i := len(entries) - 1; i >= 0; i-- {
+		if targetSteps == 0 || entries[i].stepCount <= targetSteps {
+			return entries[i].path, entries[i].stepCount, entries[i].hash, true
+		}
+	}
+	return "", 0, common.Hash{}, false
+}
+
+// lastStepCount returns the highest step count already cached for key, or 0
+// if none is cached.
+func (c *snapshotCache) lastStepCount(key snapshotKey) uint64 {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	keyPath := filepath.Join(c.dir, key.dirName())
+	entries := c.entriesByKeyPath[keyPath]
+	if len(entries) == 0 {
+		return 0
+	}
+	return entries[len(entries)-1].stepCount
+}
+
+func (c *snapshotCache) record(key snapshotKey, path string, stepCount uint64, hash common.Hash) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	keyPath := filepath.Join(c.dir, key.dirName())
+	entry := &snapshotEntry{path: path, stepCount: stepCount, hash: hash, touchedAt: time.Now()}
+	c.entriesByKeyPath[keyPath] = append(c.entriesByKeyPath[keyPath], entry)
+	c.lru = append(c.lru, entry)
+	c.evictLocked()
+}
+
+// evictLocked drops the least-recently-touched snapshots once the cache
+// holds more than maxEntries; caller must hold c.mu.
+func (c *snapshotCache) evictLocked() {
+	if c.maxEntries <= 0 || len(c.lru) <= c.maxEntries {
+		return
+	}
+	sort.Slice(c.lru, func(i, j int) bool { return c.lru[i].touchedAt.Before(c.lru[j].touchedAt) })
+	for len(c.lru) > c.maxEntries {
+		victim := c.lru[0]
+		c.lru = c.lru[1:]
+		if err := os.Remove(victim.path); err != nil && !os.IsNotExist(err) {
+			log.Warn("snapshot cache: failed to evict snapshot", "path", victim.path, "err", err)
+		}
+		keyPath := filepath.Dir(victim.path)
+		entries := c.entriesByKeyPath[keyPath]
+		for i, e := range entries {
+			if e == victim {
+				c.entriesByKeyPath[keyPath] = append(entries[:i], entries[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+func (c *snapshotCache) pathFor(key snapshotKey, stepCount uint64, hash common.Hash) (string, error) {
+	keyPath := filepath.Join(c.dir, key.dirName())
+	if err := os.MkdirAll(keyPath, 0o755); err != nil {
+		return "", err
+	}
+	return filepath.Join(keyPath, fmt.Sprintf("%d_%s.bin", stepCount, hash.Hex())), nil
+}
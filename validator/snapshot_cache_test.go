@@ -0,0 +1,89 @@
+//
+// Copyright 2021, Offchain Labs, Inc. All rights reserved.
+//
+
+package validator
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestParseSnapshotFileName(t *testing.T) {
+	hash := common.HexToHash("0x1234")
+	name := "100_" + hash.Hex() + ".bin"
+	stepCount, parsedHash, ok := parseSnapshotFileName(name)
+	if !ok {
+		t.Fatalf("expected parseSnapshotFileName to succeed for %q", name)
+	}
+	if stepCount != 100 {
+		t.Errorf("stepCount = %d, want 100", stepCount)
+	}
+	if parsedHash != hash {
+		t.Errorf("hash = %v, want %v", parsedHash, hash)
+	}
+
+	for _, bad := range []string{"nostepcount.bin", "100.bin", "abc_" + hash.Hex() + ".bin"} {
+		if _, _, ok := parseSnapshotFileName(bad); ok {
+			t.Errorf("parseSnapshotFileName(%q) = ok, want failure", bad)
+		}
+	}
+}
+
+func TestSnapshotCacheNearestBefore(t *testing.T) {
+	key := snapshotKey{BaseMachineHash: common.HexToHash("0xaa"), BatchNum: 1, PosInBatch: 2}
+	c := &snapshotCache{
+		dir:              "/tmp/snapshot-cache-test",
+		entriesByKeyPath: make(map[string][]*snapshotEntry),
+	}
+
+	if _, _, _, found := c.nearestBefore(key, 0); found {
+		t.Fatalf("expected no snapshot in an empty cache")
+	}
+
+	c.record(key, "step-100", 100, common.HexToHash("0x100"))
+	c.record(key, "step-200", 200, common.HexToHash("0x200"))
+	c.record(key, "step-300", 300, common.HexToHash("0x300"))
+
+	path, stepCount, hash, found := c.nearestBefore(key, 250)
+	if !found || path != "step-200" || stepCount != 200 || hash != common.HexToHash("0x200") {
+		t.Fatalf("nearestBefore(250) = %q, %d, %v, %v; want step-200, 200, 0x200, true", path, stepCount, hash, found)
+	}
+
+	path, stepCount, _, found = c.nearestBefore(key, 0)
+	if !found || path != "step-300" || stepCount != 300 {
+		t.Fatalf("nearestBefore(0) = %q, %d, _, %v; want step-300, 300, true", path, stepCount, found)
+	}
+
+	if _, _, _, found := c.nearestBefore(key, 50); found {
+		t.Fatalf("expected no snapshot before step 50")
+	}
+}
+
+func TestSnapshotCacheEvictLocked(t *testing.T) {
+	key := snapshotKey{BaseMachineHash: common.HexToHash("0xbb"), BatchNum: 1, PosInBatch: 0}
+	c := &snapshotCache{
+		dir:              "/tmp/snapshot-cache-test",
+		maxEntries:       2,
+		entriesByKeyPath: make(map[string][]*snapshotEntry),
+	}
+
+	// Snapshots live in their key's directory, as pathFor lays them out;
+	// evictLocked relies on that to find the victim's key.
+	keyPath := filepath.Join(c.dir, key.dirName())
+	c.record(key, filepath.Join(keyPath, "step-100"), 100, common.HexToHash("0x100"))
+	c.record(key, filepath.Join(keyPath, "step-200"), 200, common.HexToHash("0x200"))
+	c.record(key, filepath.Join(keyPath, "step-300"), 300, common.HexToHash("0x300"))
+
+	if len(c.lru) != c.maxEntries {
+		t.Fatalf("len(lru) = %d, want %d", len(c.lru), c.maxEntries)
+	}
+	if _, _, _, found := c.nearestBefore(key, 100); found {
+		t.Errorf("expected the oldest snapshot (step 100) to have been evicted")
+	}
+	if _, _, _, found := c.nearestBefore(key, 300); !found {
+		t.Errorf("expected the newest snapshot (step 300) to still be cached")
+	}
+}
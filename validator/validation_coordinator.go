@@ -0,0 +1,140 @@
+//
+// Copyright 2021, Offchain Labs, Inc. All rights reserved.
+//
+
+package validator
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	"github.com/krzkaczor/nitro/validator/validationproto"
+)
+
+// validationSpawner runs a ValidationInput to completion and returns the
+// global state reached. BlockValidator.validate calls through this
+// indirection so it doesn't care whether the work happened in-process or on
+// a remote worker.
+type validationSpawner interface {
+	Launch(ctx context.Context, input *ValidationInput) (*ValidationResult, error)
+}
+
+// localSpawner runs validation against the coordinator's own baseMachine,
+// the behavior BlockValidator always had before remote workers existed.
+type localSpawner struct {
+	baseMachine *ArbitratorMachine
+	snapshots   *snapshotCache
+}
+
+func (s *localSpawner) Launch(ctx context.Context, input *ValidationInput) (*ValidationResult, error) {
+	return runValidation(ctx, s.baseMachine, input, s.snapshots)
+}
+
+type validationWorker struct {
+	endpoint string
+	conn     *grpc.ClientConn
+	client   validationproto.ValidationServiceClient
+}
+
+// remoteValidationCoordinator pools gRPC connections to a fixed set of
+// worker endpoints and round-robins validation jobs across them. This lets
+// ConcurrentRunsLimit scale across machines instead of being bound to
+// runtime.NumCPU() on one host.
+type remoteValidationCoordinator struct {
+	mu      sync.Mutex
+	workers []*validationWorker
+	next    uint32
+}
+
+// maxLaunchAttempts bounds how many attempts, each on the next worker in
+// turn, we'll make for a single job before giving up; Launch raises it to
+// len(endpoints) when there are more workers, so a single disconnected
+// worker can't fail a job outright.
+const maxLaunchAttempts = 3
+
+func newRemoteValidationCoordinator(endpoints []string) (*remoteValidationCoordinator, error) {
+	c := &remoteValidationCoordinator{}
+	for _, endpoint := range endpoints {
+		worker, err := dialValidationWorker(endpoint)
+		if err != nil {
+			return nil, err
+		}
+		c.workers = append(c.workers, worker)
+	}
+	return c, nil
+}
+
+func dialValidationWorker(endpoint string) (*validationWorker, error) {
+	conn, err := grpc.Dial(endpoint, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+	return &validationWorker{
+		endpoint: endpoint,
+		conn:     conn,
+		client:   validationproto.NewValidationServiceClient(conn),
+	}, nil
+}
+
+func (c *remoteValidationCoordinator) pickWorker(first uint32, attempt int) *validationWorker {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.workers) == 0 {
+		return nil
+	}
+	idx := (int(first%uint32(len(c.workers))) + attempt) % len(c.workers)
+	return c.workers[idx]
+}
+
+func (c *remoteValidationCoordinator) Launch(ctx context.Context, input *ValidationInput) (*ValidationResult, error) {
+	req := validationInputToProto(input)
+	attempts := maxLaunchAttempts
+	if len(c.workers) > attempts {
+		attempts = len(c.workers)
+	}
+	first := atomic.AddUint32(&c.next, 1)
+	var lastErr error
+	for attempt := 0; attempt < attempts; attempt++ {
+		worker := c.pickWorker(first, attempt)
+		if worker == nil {
+			return nil, errors.New("no validation workers configured")
+		}
+		resp, err := worker.client.Validate(ctx, req)
+		if err != nil {
+			// grpc-go surfaces a canceled/expired ctx as a status error,
+			// which doesn't satisfy errors.Is(err, context.Canceled); the
+			// caller relies on exactly that check to return quietly
+			// instead of panicking, so translate it back here rather than
+			// burning the remaining attempts retrying a dead context.
+			switch status.Code(err) {
+			case codes.Canceled, codes.DeadlineExceeded:
+				return nil, ctx.Err()
+			}
+			log.Warn("validation worker unreachable, retrying on another worker", "endpoint", worker.endpoint, "attempt", attempt, "err", err)
+			lastErr = err
+			continue
+		}
+		return &ValidationResult{
+			Batch:      resp.Batch,
+			PosInBatch: resp.PosInBatch,
+			BlockHash:  common.BytesToHash(resp.BlockHash),
+			Steps:      resp.Steps,
+		}, nil
+	}
+	return nil, lastErr
+}
+
+func (c *remoteValidationCoordinator) Close() {
+	for _, worker := range c.workers {
+		_ = worker.conn.Close()
+	}
+}
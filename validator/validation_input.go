@@ -0,0 +1,63 @@
+//
+// Copyright 2021, Offchain Labs, Inc. All rights reserved.
+//
+
+package validator
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ValidationInput is the self-contained unit of work needed to replay a
+// single block: the sequencer message and (optional) delayed message that
+// produced it, the preimages the replay will need to resolve, and the start
+// and end inbox positions the result is checked against. It carries no cgo
+// types so it can be serialized and shipped to a remote validation worker.
+type ValidationInput struct {
+	BlockNumber   uint64
+	PrevBlockHash common.Hash
+	BlockHash     common.Hash
+	Start         PosInSequencer
+	End           PosInSequencer
+	HasDelayedMsg bool
+	DelayedMsgNr  uint64
+	SequencerMsg  []byte
+	DelayedMsg    []byte
+	Preimages     map[common.Hash][]byte
+}
+
+// ValidationResult is the outcome of replaying a ValidationInput: the global
+// state the machine actually reached, and how many prover steps it took to
+// get there.
+type ValidationResult struct {
+	Batch      uint64
+	PosInBatch uint64
+	BlockHash  common.Hash
+	Steps      uint64
+}
+
+func (v *BlockValidator) newValidationInput(entry *validationEntry, start, end PosInSequencer, sequencerMsg, delayedMsg []byte) (*ValidationInput, error) {
+	preimages := make(map[common.Hash][]byte, len(entry.Preimages))
+	for _, hash := range entry.Preimages {
+		data, err := v.preimageCache.GetBytes(hash)
+		if err != nil {
+			v.metrics.preimageCacheMisses.Inc()
+			return nil, err
+		}
+		v.metrics.preimageCacheHits.Inc()
+		preimages[hash] = data
+	}
+	v.metrics.preimageCacheSize.Set(float64(v.preimageCache.Len()))
+	return &ValidationInput{
+		BlockNumber:   entry.BlockNumber,
+		PrevBlockHash: entry.PrevBlockHash,
+		BlockHash:     entry.BlockHash,
+		Start:         start,
+		End:           end,
+		HasDelayedMsg: entry.HasDelayedMsg,
+		DelayedMsgNr:  entry.DelayedMsgNr,
+		SequencerMsg:  sequencerMsg,
+		DelayedMsg:    delayedMsg,
+		Preimages:     preimages,
+	}, nil
+}
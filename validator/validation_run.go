@@ -0,0 +1,133 @@
+//
+// Copyright 2021, Offchain Labs, Inc. All rights reserved.
+//
+
+package validator
+
+/*
+#cgo CFLAGS: -g -Wall -I../arbitrator/target/env/include/
+#include "arbitrator.h"
+#include <stdlib.h>
+*/
+import "C"
+import (
+	"context"
+	"unsafe"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/pkg/errors"
+)
+
+// runValidation replays a ValidationInput against a clone of baseMachine and
+// returns the global state the machine reached. It has no dependency on
+// BlockValidator's bookkeeping (channels, caches, atomics) so it can run
+// equally well in-process or inside a remote validation worker. snapshots
+// may be nil, in which case every run replays from input.Start.
+func runValidation(ctx context.Context, baseMachine *ArbitratorMachine, input *ValidationInput, snapshots *snapshotCache) (*ValidationResult, error) {
+	key := snapshotKey{
+		BaseMachineHash: baseMachine.Hash(),
+		BatchNum:        input.Start.BatchNum,
+		PosInBatch:      input.Start.PosInBatch,
+	}
+
+	mach, resumedAtSteps := loadNearestSnapshot(baseMachine, key, snapshots)
+	if resumedAtSteps > 0 {
+		log.Info("resuming validation from cached snapshot", "block", input.BlockNumber, "atStep", resumedAtSteps)
+	}
+
+	// DeserializeAndReplaceState only restores the machine's VM state; it
+	// does not re-register the native-side preimages or inbox messages
+	// tied to this specific *ArbitratorMachine, so this must run
+	// unconditionally, even when resuming from a cached snapshot.
+	hashes := make([][]byte, 0, len(input.Preimages))
+	datas := make([][]byte, 0, len(input.Preimages))
+	for hash, data := range input.Preimages {
+		hashes = append(hashes, hash.Bytes())
+		datas = append(datas, data)
+	}
+	cPreimages := CreateCMultipleByteArrays(hashes, datas)
+	defer C.free(unsafe.Pointer(cPreimages.ptr))
+	C.arbitrator_add_preimages(mach.ptr, cPreimages)
+
+	if resumedAtSteps == 0 {
+		gsStart := CreateGlobalState(input.Start.BatchNum, input.Start.PosInBatch, input.PrevBlockHash)
+		mach.SetGlobalState(gsStart)
+	}
+
+	seqCByte := CreateCByteArray(input.SequencerMsg)
+	defer DestroyCByteArray(seqCByte)
+	mach.AddSequencerInboxMessage(input.Start.BatchNum, seqCByte)
+
+	if input.HasDelayedMsg {
+		delayedCByte := CreateCByteArray(input.DelayedMsg)
+		defer DestroyCByteArray(delayedCByte)
+		mach.AddDelayedInboxMessage(input.DelayedMsgNr, delayedCByte)
+	}
+
+	steps := resumedAtSteps
+	lastSnapshotSteps := resumedAtSteps
+	for mach.IsRunning() {
+		var count uint64 = 100000000
+		err := mach.Step(ctx, count)
+		if err != nil {
+			if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+				return nil, err
+			}
+			log.Error("running machine failed", "err", err)
+			panic("Failed to run machine: " + err.Error())
+		}
+		steps += count
+		log.Info("validation", "block", input.BlockNumber, "steps", steps)
+
+		if snapshots != nil && snapshots.stepInterval > 0 && steps-lastSnapshotSteps >= snapshots.stepInterval {
+			saveSnapshot(snapshots, mach, key, steps)
+			lastSnapshotSteps = steps
+		}
+	}
+	gsEnd := mach.GetGlobalState()
+	resBatch, resPosInBatch, resHash := ParseGlobalState(gsEnd)
+	return &ValidationResult{
+		Batch:      resBatch,
+		PosInBatch: resPosInBatch,
+		BlockHash:  resHash,
+		Steps:      steps,
+	}, nil
+}
+
+// loadNearestSnapshot returns a machine ready to keep stepping and how many
+// steps into the validation it already represents. On any failure to find
+// or trust a cached snapshot it falls back to a fresh clone of baseMachine
+// (0 steps), exactly like cacheBaseMachineUntilHostIo falls back to
+// replaying when its own single checkpoint can't be loaded.
+func loadNearestSnapshot(baseMachine *ArbitratorMachine, key snapshotKey, snapshots *snapshotCache) (*ArbitratorMachine, uint64) {
+	path, stepCount, hash, found := snapshots.nearestBefore(key, 0)
+	if !found {
+		return baseMachine.Clone(), 0
+	}
+	mach := baseMachine.Clone()
+	if err := mach.DeserializeAndReplaceState(path); err != nil {
+		log.Warn("snapshot cache: failed to load snapshot, replaying from start", "path", path, "err", err)
+		return baseMachine.Clone(), 0
+	}
+	if snapshots.verifyOnLoad {
+		if actual := mach.Hash(); actual != hash {
+			log.Error("snapshot cache: hash mismatch, discarding snapshot", "path", path, "expected", hash, "actual", actual)
+			return baseMachine.Clone(), 0
+		}
+	}
+	return mach, stepCount
+}
+
+func saveSnapshot(snapshots *snapshotCache, mach *ArbitratorMachine, key snapshotKey, steps uint64) {
+	hash := mach.Hash()
+	path, err := snapshots.pathFor(key, steps, hash)
+	if err != nil {
+		log.Warn("snapshot cache: failed to allocate path", "err", err)
+		return
+	}
+	if err := mach.SerializeState(path); err != nil {
+		log.Warn("snapshot cache: failed to serialize state", "path", path, "err", err)
+		return
+	}
+	snapshots.record(key, path, steps, hash)
+}
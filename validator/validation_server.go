@@ -0,0 +1,137 @@
+//
+// Copyright 2021, Offchain Labs, Inc. All rights reserved.
+//
+
+package validator
+
+import (
+	"context"
+	"net"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"google.golang.org/grpc"
+
+	"github.com/krzkaczor/nitro/validator/validationproto"
+)
+
+// ValidationServer exposes the validate unit of work over gRPC so that
+// catch-up validation can be scaled across machines instead of being bound
+// to runtime.NumCPU() on a single host. It holds no chain state: everything
+// a worker needs to replay a block travels in the ValidationRequest.
+type ValidationServer struct {
+	validationproto.UnimplementedValidationServiceServer
+
+	baseMachine *ArbitratorMachine
+	snapshots   *snapshotCache
+	grpcServer  *grpc.Server
+}
+
+// NewValidationServer loads the same prover/module binaries a local
+// BlockValidator would use, so a worker started with this config validates
+// identically to in-process validation.
+func NewValidationServer(config *BlockValidatorConfig) (*ValidationServer, error) {
+	baseMachine, err := loadBaseMachine(config)
+	if err != nil {
+		return nil, err
+	}
+	snapshots, err := newSnapshotCache(config)
+	if err != nil {
+		return nil, err
+	}
+	return &ValidationServer{baseMachine: baseMachine, snapshots: snapshots}, nil
+}
+
+func (s *ValidationServer) Validate(ctx context.Context, req *validationproto.ValidationRequest) (*validationproto.ValidationResponse, error) {
+	input, err := validationInputFromProto(req)
+	if err != nil {
+		return nil, err
+	}
+	result, err := runValidation(ctx, s.baseMachine, input, s.snapshots)
+	if err != nil {
+		return nil, err
+	}
+	return &validationproto.ValidationResponse{
+		Batch:      result.Batch,
+		PosInBatch: result.PosInBatch,
+		BlockHash:  result.BlockHash.Bytes(),
+		Steps:      result.Steps,
+	}, nil
+}
+
+// Listen blocks serving ValidationService on listenAddr until ctx is done.
+func (s *ValidationServer) Listen(ctx context.Context, listenAddr string) error {
+	lis, err := net.Listen("tcp", listenAddr)
+	if err != nil {
+		return err
+	}
+	s.grpcServer = grpc.NewServer()
+	validationproto.RegisterValidationServiceServer(s.grpcServer, s)
+	go func() {
+		<-ctx.Done()
+		s.grpcServer.GracefulStop()
+	}()
+	log.Info("validation worker listening", "addr", listenAddr)
+	return s.grpcServer.Serve(lis)
+}
+
+func validationInputFromProto(req *validationproto.ValidationRequest) (*ValidationInput, error) {
+	preimages := make(map[common.Hash][]byte, len(req.Preimages))
+	for _, p := range req.Preimages {
+		preimages[common.BytesToHash(p.Hash)] = p.Data
+	}
+	return &ValidationInput{
+		BlockNumber:   req.BlockNumber,
+		PrevBlockHash: common.BytesToHash(req.PrevBlockHash),
+		BlockHash:     common.BytesToHash(req.BlockHash),
+		Start:         posInSequencerFromProto(req.Start),
+		End:           posInSequencerFromProto(req.End),
+		HasDelayedMsg: req.HasDelayedMsg,
+		DelayedMsgNr:  req.DelayedMsgNr,
+		SequencerMsg:  req.SequencerMsg,
+		DelayedMsg:    req.DelayedMsg,
+		Preimages:     preimages,
+	}, nil
+}
+
+func posInSequencerFromProto(p *validationproto.PosInSequencer) PosInSequencer {
+	if p == nil {
+		return PosInSequencer{}
+	}
+	return PosInSequencer{
+		Pos:        p.Pos,
+		BatchNum:   p.BatchNum,
+		PosInBatch: p.PosInBatch,
+		BatchAfter: p.BatchAfter,
+		PosAfter:   p.PosAfter,
+	}
+}
+
+func posInSequencerToProto(p PosInSequencer) *validationproto.PosInSequencer {
+	return &validationproto.PosInSequencer{
+		Pos:        p.Pos,
+		BatchNum:   p.BatchNum,
+		PosInBatch: p.PosInBatch,
+		BatchAfter: p.BatchAfter,
+		PosAfter:   p.PosAfter,
+	}
+}
+
+func validationInputToProto(input *ValidationInput) *validationproto.ValidationRequest {
+	preimages := make([]*validationproto.Preimage, 0, len(input.Preimages))
+	for hash, data := range input.Preimages {
+		preimages = append(preimages, &validationproto.Preimage{Hash: hash.Bytes(), Data: data})
+	}
+	return &validationproto.ValidationRequest{
+		BlockNumber:   input.BlockNumber,
+		PrevBlockHash: input.PrevBlockHash.Bytes(),
+		BlockHash:     input.BlockHash.Bytes(),
+		Start:         posInSequencerToProto(input.Start),
+		End:           posInSequencerToProto(input.End),
+		HasDelayedMsg: input.HasDelayedMsg,
+		DelayedMsgNr:  input.DelayedMsgNr,
+		SequencerMsg:  input.SequencerMsg,
+		DelayedMsg:    input.DelayedMsg,
+		Preimages:     preimages,
+	}
+}
@@ -0,0 +1,186 @@
+//
+// Copyright 2021, Offchain Labs, Inc. All rights reserved.
+//
+
+package validator
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/ethdb/leveldb"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// The validator persists just enough to resume catch-up validation after a
+// crash or an orderly shutdown without redoing work: the last checkpoint
+// that's fully validated, the sequencer positions still pending, and the
+// raw batch bytes those pending positions will be checked against. Blocks
+// themselves are never persisted here; they're re-requested from the
+// streamer once validation resumes at posNext.
+var (
+	validatedCheckpointKey = []byte("bv-validated")
+	pendingPosPrefix       = []byte("bv-pending-")
+	batchBytesPrefix       = []byte("bv-batch-")
+)
+
+// validatedCheckpoint is the durable record of validation progress.
+type validatedCheckpoint struct {
+	PosNext          uint64
+	BatchNrValidated uint64
+	BlocksValidated  uint64
+}
+
+func openValidatorDb(config *BlockValidatorConfig) (ethdb.Database, error) {
+	if config.ValidatorDbPath == "" {
+		return nil, nil
+	}
+	dbPath := config.ValidatorDbPath
+	if !filepath.IsAbs(dbPath) {
+		dbPath = filepath.Join(config.RootPath, dbPath)
+	}
+	return leveldb.New(dbPath, 0, 0, "blockvalidator/", false)
+}
+
+func pendingPosKey(pos uint64) []byte {
+	key := make([]byte, len(pendingPosPrefix)+8)
+	copy(key, pendingPosPrefix)
+	binary.BigEndian.PutUint64(key[len(pendingPosPrefix):], pos)
+	return key
+}
+
+func batchBytesKey(batchNr uint64) []byte {
+	key := make([]byte, len(batchBytesPrefix)+8)
+	copy(key, batchBytesPrefix)
+	binary.BigEndian.PutUint64(key[len(batchBytesPrefix):], batchNr)
+	return key
+}
+
+func (v *BlockValidator) persistCheckpoint() error {
+	if v.db == nil {
+		return nil
+	}
+	checkpoint := validatedCheckpoint{
+		PosNext:          v.posNext,
+		BatchNrValidated: v.batchNrValidated,
+		BlocksValidated:  v.blocksValidated,
+	}
+	encoded, err := json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+	return v.db.Put(validatedCheckpointKey, encoded)
+}
+
+func (v *BlockValidator) loadCheckpoint() (*validatedCheckpoint, error) {
+	if v.db == nil {
+		return nil, nil
+	}
+	has, err := v.db.Has(validatedCheckpointKey)
+	if err != nil || !has {
+		return nil, err
+	}
+	encoded, err := v.db.Get(validatedCheckpointKey)
+	if err != nil {
+		return nil, err
+	}
+	checkpoint := &validatedCheckpoint{}
+	if err := json.Unmarshal(encoded, checkpoint); err != nil {
+		return nil, err
+	}
+	return checkpoint, nil
+}
+
+// persistPending records a sequencer position that's been queued for
+// validation but hasn't completed yet, so it survives a restart.
+func (v *BlockValidator) persistPending(pos PosInSequencer) error {
+	if v.db == nil {
+		return nil
+	}
+	encoded, err := json.Marshal(pos)
+	if err != nil {
+		return err
+	}
+	return v.db.Put(pendingPosKey(pos.Pos), encoded)
+}
+
+// persistBatch records the raw sequencer message bytes for batchNr, so a
+// pending position can be re-validated after a restart without waiting for
+// the inbox tracker to replay the batch.
+func (v *BlockValidator) persistBatch(batchNr uint64, msg []byte) error {
+	if v.db == nil {
+		return nil
+	}
+	return v.db.Put(batchBytesKey(batchNr), msg)
+}
+
+func (v *BlockValidator) loadPendingPositions() ([]PosInSequencer, error) {
+	if v.db == nil {
+		return nil, nil
+	}
+	it := v.db.NewIterator(pendingPosPrefix, nil)
+	defer it.Release()
+	var positions []PosInSequencer
+	for it.Next() {
+		var pos PosInSequencer
+		if err := json.Unmarshal(it.Value(), &pos); err != nil {
+			log.Error("validator: corrupt pending position in db, skipping", "err", err)
+			continue
+		}
+		positions = append(positions, pos)
+	}
+	return positions, it.Error()
+}
+
+func (v *BlockValidator) loadBatchBytes(batchNr uint64) ([]byte, bool, error) {
+	if v.db == nil {
+		return nil, false, nil
+	}
+	has, err := v.db.Has(batchBytesKey(batchNr))
+	if err != nil || !has {
+		return nil, false, err
+	}
+	data, err := v.db.Get(batchBytesKey(batchNr))
+	return data, err == nil, err
+}
+
+// compactValidated drops the persisted pending-position and batch-bytes
+// records that are now behind the last checkpoint; they'll never be needed
+// to resume from again.
+func (v *BlockValidator) compactValidated(validatedThrough uint64, batchNrValidated uint64) error {
+	if v.db == nil {
+		return nil
+	}
+	batch := v.db.NewBatch()
+	it := v.db.NewIterator(pendingPosPrefix, nil)
+	for it.Next() {
+		pos := binary.BigEndian.Uint64(it.Key()[len(pendingPosPrefix):])
+		if pos <= validatedThrough {
+			if err := batch.Delete(append([]byte{}, it.Key()...)); err != nil {
+				it.Release()
+				return err
+			}
+		}
+	}
+	it.Release()
+	if err := it.Error(); err != nil {
+		return err
+	}
+	batchIt := v.db.NewIterator(batchBytesPrefix, nil)
+	for batchIt.Next() {
+		batchNr := binary.BigEndian.Uint64(batchIt.Key()[len(batchBytesPrefix):])
+		if batchNr < batchNrValidated {
+			if err := batch.Delete(append([]byte{}, batchIt.Key()...)); err != nil {
+				batchIt.Release()
+				return err
+			}
+		}
+	}
+	batchIt.Release()
+	if err := batchIt.Error(); err != nil {
+		return err
+	}
+	return batch.Write()
+}
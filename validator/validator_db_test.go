@@ -0,0 +1,86 @@
+//
+// Copyright 2021, Offchain Labs, Inc. All rights reserved.
+//
+
+package validator
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+)
+
+func TestValidatorDbCheckpointRoundTrip(t *testing.T) {
+	v := &BlockValidator{db: memorydb.New()}
+
+	if checkpoint, err := v.loadCheckpoint(); err != nil || checkpoint != nil {
+		t.Fatalf("loadCheckpoint on empty db = %+v, %v; want nil, nil", checkpoint, err)
+	}
+
+	v.posNext = 42
+	v.batchNrValidated = 7
+	v.blocksValidated = 41
+	if err := v.persistCheckpoint(); err != nil {
+		t.Fatalf("persistCheckpoint: %v", err)
+	}
+
+	loaded, err := v.loadCheckpoint()
+	if err != nil {
+		t.Fatalf("loadCheckpoint: %v", err)
+	}
+	if loaded.PosNext != 42 || loaded.BatchNrValidated != 7 || loaded.BlocksValidated != 41 {
+		t.Fatalf("loadCheckpoint = %+v, want {42 7 41}", loaded)
+	}
+}
+
+func TestValidatorDbPendingAndBatchRoundTrip(t *testing.T) {
+	v := &BlockValidator{db: memorydb.New()}
+
+	if err := v.persistPending(PosInSequencer{Pos: 1, BatchNum: 1}); err != nil {
+		t.Fatalf("persistPending(1): %v", err)
+	}
+	if err := v.persistPending(PosInSequencer{Pos: 2, BatchNum: 1}); err != nil {
+		t.Fatalf("persistPending(2): %v", err)
+	}
+	if err := v.persistBatch(1, []byte("batch-1")); err != nil {
+		t.Fatalf("persistBatch(1): %v", err)
+	}
+	if err := v.persistBatch(2, []byte("batch-2")); err != nil {
+		t.Fatalf("persistBatch(2): %v", err)
+	}
+
+	positions, err := v.loadPendingPositions()
+	if err != nil {
+		t.Fatalf("loadPendingPositions: %v", err)
+	}
+	if len(positions) != 2 {
+		t.Fatalf("len(positions) = %d, want 2", len(positions))
+	}
+
+	data, found, err := v.loadBatchBytes(1)
+	if err != nil || !found || !bytes.Equal(data, []byte("batch-1")) {
+		t.Fatalf("loadBatchBytes(1) = %q, %v, %v", data, found, err)
+	}
+
+	// compactValidated should drop pos 1 (<= validatedThrough) and batch 1
+	// (< batchNrValidated), but keep pos 2 and batch 2.
+	if err := v.compactValidated(1, 2); err != nil {
+		t.Fatalf("compactValidated: %v", err)
+	}
+
+	positions, err = v.loadPendingPositions()
+	if err != nil {
+		t.Fatalf("loadPendingPositions after compaction: %v", err)
+	}
+	if len(positions) != 1 || positions[0].Pos != 2 {
+		t.Fatalf("loadPendingPositions after compaction = %+v, want [{Pos:2}]", positions)
+	}
+
+	if _, found, err := v.loadBatchBytes(1); err != nil || found {
+		t.Fatalf("loadBatchBytes(1) after compaction: found=%v, err=%v; want found=false", found, err)
+	}
+	if data, found, err := v.loadBatchBytes(2); err != nil || !found || !bytes.Equal(data, []byte("batch-2")) {
+		t.Fatalf("loadBatchBytes(2) after compaction = %q, %v, %v", data, found, err)
+	}
+}